@@ -0,0 +1,198 @@
+package fileutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// checksumRecord builds the "relpath|mode|size|contentSHA" record used by
+// Checksum and ChecksumGlob. contentSHA is the hash of the symlink target
+// instead of file content when the entry is a symlink and followLinks is
+// false.
+func checksumRecord(base, path string, info os.FileInfo, followLinks bool) (string, error) {
+	relPath, err := filepath.Rel(base, path)
+	if err != nil {
+		return "", err
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	var contentSHA string
+	if info.Mode()&os.ModeSymlink != 0 && !followLinks {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256([]byte(target))
+		contentSHA = hex.EncodeToString(sum[:])
+	} else {
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return "", err
+		}
+		contentSHA = sum
+	}
+
+	return fmt.Sprintf("%s|%o|%d|%s", relPath, info.Mode(), info.Size(), contentSHA), nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// digestRecords hashes the sorted record set, giving a checksum that is
+// stable regardless of directory walk order.
+func digestRecords(records []string) string {
+	sort.Strings(records)
+	h := sha256.New()
+	for _, r := range records {
+		io.WriteString(h, r)
+		io.WriteString(h, "\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func collectChecksumRecords(root string, followLinks bool) ([]string, error) {
+	info, err := os.Lstat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if isDirEntry(root, info, followLinks) {
+		return walkChecksumDir(root, root, followLinks, nil)
+	}
+
+	record, err := checksumRecord(filepath.Dir(root), root, info, followLinks)
+	if err != nil {
+		return nil, err
+	}
+	return []string{record}, nil
+}
+
+// isDirEntry reports whether path should be treated as a directory to
+// recurse into: either info (an Lstat result) is itself a directory, or it's
+// a symlink and followLinks is true and the symlink resolves to a directory.
+func isDirEntry(path string, info os.FileInfo, followLinks bool) bool {
+	if info.IsDir() {
+		return true
+	}
+	if info.Mode()&os.ModeSymlink == 0 || !followLinks {
+		return false
+	}
+	target, err := os.Stat(path)
+	return err == nil && target.IsDir()
+}
+
+// walkChecksumDir records one entry per file found under dir (hashed
+// relative to base), using Lstat-based directory listings so that, unlike
+// filepath.Walk, a symlink pointing at a directory can be told apart from a
+// real one. When followLinks is true, such symlinks are recursed into rather
+// than read as file content. ancestors holds the resolved path of every
+// directory currently being walked above dir on this branch, so a symlink
+// that loops back on one of its own ancestors is caught instead of
+// recursing forever; visiting the same real directory via two unrelated
+// paths (e.g. a symlink alongside the directory it targets) is not a cycle
+// and is walked both times.
+func walkChecksumDir(base, dir string, followLinks bool, ancestors map[string]bool) ([]string, error) {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		resolved = dir
+	}
+	if ancestors[resolved] {
+		return nil, nil
+	}
+	ancestors = mergeAncestor(ancestors, resolved)
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+
+		if isDirEntry(path, entry, followLinks) {
+			sub, err := walkChecksumDir(base, path, followLinks, ancestors)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, sub...)
+			continue
+		}
+
+		record, err := checksumRecord(base, path, entry, followLinks)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// mergeAncestor returns a copy of ancestors with resolved added, leaving the
+// caller's map untouched so sibling branches of the walk don't see each
+// other's ancestry.
+func mergeAncestor(ancestors map[string]bool, resolved string) map[string]bool {
+	merged := make(map[string]bool, len(ancestors)+1)
+	for k := range ancestors {
+		merged[k] = true
+	}
+	merged[resolved] = true
+	return merged
+}
+
+// Checksum returns a stable content+metadata digest for path: a sha256 over
+// the sorted set of "relpath|mode|size|contentSHA" records for every file
+// under path (or just path itself, if it is a file). Symlinks are hashed by
+// their target rather than followed unless followLinks is true. This lets
+// callers detect whether a Cp would actually change anything before running
+// it.
+func Checksum(path string, followLinks bool) (string, error) {
+	records, err := collectChecksumRecords(path, followLinks)
+	if err != nil {
+		return "", err
+	}
+	return digestRecords(records), nil
+}
+
+// ChecksumGlob is like Checksum but operates over every path matched by
+// pattern (a Glob pattern, optionally containing "**" segments), combining
+// all of their records into a single digest.
+func ChecksumGlob(pattern string, followLinks bool) (string, error) {
+	matches, err := Glob(pattern)
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", &os.PathError{Op: "checksumglob", Path: pattern, Err: os.ErrNotExist}
+	}
+
+	var records []string
+	for _, match := range matches {
+		matchRecords, err := collectChecksumRecords(match, followLinks)
+		if err != nil {
+			return "", err
+		}
+		records = append(records, matchRecords...)
+	}
+
+	return digestRecords(records), nil
+}
@@ -0,0 +1,108 @@
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChecksumStableAcrossWalkOrder(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "a.txt", "sub/b.txt", "sub/c.txt")
+
+	sum1, err := Checksum(root, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum2, err := Checksum(root, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum1 != sum2 {
+		t.Fatalf("expected stable checksum, got %s and %s", sum1, sum2)
+	}
+}
+
+func TestChecksumChangesWithContent(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "a.txt")
+
+	before, err := Checksum(root, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := Checksum(root, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if before == after {
+		t.Fatalf("expected checksum to change after content edit, got %s both times", before)
+	}
+}
+
+func TestChecksumFollowsSymlinkedDirectory(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "real/a.txt")
+	link := filepath.Join(root, "linked")
+	if err := os.Symlink(filepath.Join(root, "real"), link); err != nil {
+		t.Fatal(err)
+	}
+
+	// followLinks=true must recurse into the symlinked directory's contents
+	// rather than trying to read the directory as file content, so it picks
+	// up both real/a.txt and the same file again under linked/.
+	followedRecords, err := collectChecksumRecords(root, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(followedRecords) != 2 {
+		t.Fatalf("expected 2 records (real/a.txt and linked/a.txt), got %v", followedRecords)
+	}
+
+	// followLinks=false must hash the symlink itself (its target string),
+	// not descend into it, so linked/ contributes a single record.
+	unfollowedRecords, err := collectChecksumRecords(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unfollowedRecords) != 2 {
+		t.Fatalf("expected 2 records (real/a.txt and the linked symlink itself), got %v", unfollowedRecords)
+	}
+
+	followed, err := Checksum(root, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unfollowed, err := Checksum(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if followed == unfollowed {
+		t.Fatalf("expected followLinks=false checksum to differ from followLinks=true, got %s for both", followed)
+	}
+}
+
+func TestChecksumGlobMatchesChecksumOfSingleFile(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "a.txt")
+
+	direct, err := Checksum(filepath.Join(root, "a.txt"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	glob, err := ChecksumGlob(filepath.Join(root, "a.txt"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if direct != glob {
+		t.Fatalf("expected ChecksumGlob to match Checksum for a single file, got %s vs %s", glob, direct)
+	}
+}
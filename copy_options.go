@@ -0,0 +1,238 @@
+package fileutils
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// SymlinkAction controls how CpWithOptions handles a symlink encountered in
+// the source tree.
+type SymlinkAction int
+
+const (
+	// SymlinkDeep follows the symlink and copies the file or directory it
+	// points to.
+	SymlinkDeep SymlinkAction = iota
+	// SymlinkShallow recreates the symlink itself at the destination.
+	SymlinkShallow
+	// SymlinkSkip leaves the symlink out of the copy entirely.
+	SymlinkSkip
+)
+
+// DirExistsAction controls how CpWithOptions handles a destination directory
+// that already exists.
+type DirExistsAction int
+
+const (
+	// DirReplace removes the existing destination directory before copying.
+	DirReplace DirExistsAction = iota
+	// DirMerge copies into the existing destination directory, overwriting
+	// any files that collide.
+	DirMerge
+	// DirSkip leaves the existing destination directory untouched.
+	DirSkip
+)
+
+// CpOptions configures CpWithOptions. The zero value behaves like
+// CpWithArgs(src, dest, CpArgs{}): a non-recursive copy that follows
+// symlinks, and that aborts if a destination directory already exists.
+type CpOptions struct {
+	Recursive          bool
+	PreserveLinks      bool
+	PreserveTimestamps bool
+	PreserveTimes      bool
+
+	// OnSymlink is consulted for every symlink in the source tree; if nil,
+	// symlinks are followed (SymlinkDeep), unless PreserveLinks is set, in
+	// which case they're recreated as symlinks (SymlinkShallow).
+	OnSymlink func(src string) SymlinkAction
+	// OnDirExists is consulted whenever the destination directory for a
+	// recursive copy already exists; if nil, CpWithOptions fails the way
+	// CpWithArgs does today.
+	OnDirExists func(src, dest string) DirExistsAction
+	// Skip, if non-nil, is called for every source path; returning true
+	// excludes it (and, for a directory, its contents) from the copy.
+	Skip func(src string) (bool, error)
+
+	// AddPermission is OR'd into every copied entry's mode, capped at 0777.
+	AddPermission os.FileMode
+	// NoSync skips the fsync normally performed on a copied file after
+	// writing. Left false (the zero value), every copied file is synced,
+	// matching CpWithArgs/CpR's unconditional out.Sync().
+	NoSync bool
+}
+
+// CpWithOptions is like CpWithArgs but driven by a CpOptions, giving callers
+// control over symlink handling, destination directory collisions, path
+// filtering, permission bits, and fsync behavior.
+func CpWithOptions(src, dest string, opts CpOptions) (err error) {
+	skip, err := shouldSkip(opts, src)
+	if err != nil {
+		return err
+	}
+	if skip {
+		return nil
+	}
+
+	srcInfo, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	if srcInfo.Mode()&os.ModeSymlink != 0 {
+		return cpSymlinkWithOptions(src, dest, opts)
+	}
+
+	if srcInfo.IsDir() {
+		return cpDirWithOptions(src, dest, srcInfo, opts)
+	}
+
+	return cpFileWithOptions(src, dest, srcInfo, opts)
+}
+
+func shouldSkip(opts CpOptions, src string) (bool, error) {
+	if opts.Skip == nil {
+		return false, nil
+	}
+	return opts.Skip(src)
+}
+
+func cpSymlinkWithOptions(src, dest string, opts CpOptions) error {
+	action := SymlinkDeep
+	if opts.PreserveLinks {
+		action = SymlinkShallow
+	}
+	if opts.OnSymlink != nil {
+		action = opts.OnSymlink(src)
+	}
+
+	switch action {
+	case SymlinkSkip:
+		return nil
+	case SymlinkShallow:
+		return cpSymlink(src, dest)
+	default: // SymlinkDeep
+		info, err := os.Stat(src)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return cpDirWithOptions(src, dest, info, opts)
+		}
+		return cpFileWithOptions(src, dest, info, opts)
+	}
+}
+
+func cpDirWithOptions(src, dest string, srcInfo os.FileInfo, opts CpOptions) error {
+	if !opts.Recursive {
+		return errDirectorySource
+	}
+
+	mode := addPermission(srcInfo.Mode(), opts.AddPermission)
+
+	if destInfo, statErr := os.Stat(dest); statErr == nil {
+		action := DirReplace
+		if opts.OnDirExists != nil {
+			action = opts.OnDirExists(src, dest)
+		} else {
+			return errDestinationExists
+		}
+
+		switch action {
+		case DirSkip:
+			return nil
+		case DirReplace:
+			if err := RmRF(dest); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(dest, mode); err != nil {
+				return err
+			}
+			if opts.AddPermission != 0 {
+				if err := os.Chmod(dest, mode); err != nil {
+					return err
+				}
+			}
+		case DirMerge:
+			if !destInfo.IsDir() {
+				return errDestinationExists
+			}
+		}
+	} else {
+		if err := os.MkdirAll(dest, mode); err != nil {
+			return err
+		}
+		// MkdirAll's mode is filtered by the umask; chmod so the added
+		// bits are kept.
+		if opts.AddPermission != 0 {
+			if err := os.Chmod(dest, mode); err != nil {
+				return err
+			}
+		}
+	}
+
+	entries, err := ioutil.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := CpWithOptions(filepath.Join(src, entry.Name()), filepath.Join(dest, entry.Name()), opts); err != nil {
+			return err
+		}
+	}
+
+	if opts.PreserveTimes {
+		if err := os.Chtimes(dest, srcInfo.ModTime(), srcInfo.ModTime()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func cpFileWithOptions(src, dest string, srcInfo os.FileInfo, opts CpOptions) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := out.Close()
+		if err == nil {
+			err = cerr
+		}
+	}()
+
+	if _, err = io.Copy(out, in); err != nil {
+		return err
+	}
+
+	mode := addPermission(srcInfo.Mode(), opts.AddPermission)
+	if err = out.Chmod(mode); err != nil {
+		return err
+	}
+
+	if opts.PreserveTimestamps || opts.PreserveTimes {
+		if err = os.Chtimes(dest, srcInfo.ModTime(), srcInfo.ModTime()); err != nil {
+			return err
+		}
+	}
+
+	if !opts.NoSync {
+		err = out.Sync()
+	}
+
+	return err
+}
+
+func addPermission(mode, add os.FileMode) os.FileMode {
+	return mode | (add & 0777)
+}
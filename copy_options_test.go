@@ -0,0 +1,39 @@
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCpWithOptionsZeroValueCopiesContent(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(source, []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(root, "b.txt")
+	if err := CpWithOptions(source, dest, CpOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(dest); err != nil || string(got) != "hi" {
+		t.Fatalf("got %q, %v; want %q, nil", got, err, "hi")
+	}
+}
+
+func TestCpWithOptionsNoSyncCopiesContent(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(source, []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(root, "b.txt")
+	if err := CpWithOptions(source, dest, CpOptions{NoSync: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(dest); err != nil || string(got) != "hi" {
+		t.Fatalf("got %q, %v; want %q, nil", got, err, "hi")
+	}
+}
@@ -0,0 +1,254 @@
+package fileutils
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const defaultBufferSize = 8 * 1024
+
+// cpJob describes a single file (or symlink) to copy during a parallel
+// recursive copy. A job with linkFrom set is a deferred hardlink: it waits
+// on linkWait (closed once the original copy at linkFrom finishes) and then
+// links dst to it instead of copying content.
+type cpJob struct {
+	src, dst  string
+	mode      os.FileMode
+	modTime   time.Time
+	isSymlink bool
+	ownerInfo os.FileInfo
+
+	linkFrom   string
+	linkWait   chan struct{}
+	copiedDone chan struct{}
+}
+
+// cpRecursiveParallel implements the recursive case of CpWithArgs using a
+// worker pool of args.Workers goroutines (default runtime.NumCPU()) fed by a
+// bounded channel of file jobs. Directory creation happens sequentially on
+// the walking goroutine to preserve ordering; the first error encountered by
+// any worker or by the walk cancels every job still in flight.
+func cpRecursiveParallel(source, dest string, sourceInfo os.FileInfo, args CpArgs) error {
+	if err := os.MkdirAll(dest, sourceInfo.Mode()); err != nil {
+		return err
+	}
+
+	workers := args.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	bufferSize := args.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	bufPool := &sync.Pool{
+		New: func() interface{} {
+			buf := make([]byte, bufferSize)
+			return &buf
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobs := make(chan cpJob, workers*2)
+	errCh := make(chan error, 1)
+	var progressMu sync.Mutex
+	var bytesCopied, filesCopied int64
+
+	reportErr := func(err error) {
+		select {
+		case errCh <- err:
+			cancel()
+		default:
+		}
+	}
+
+	var hardlinks *hardlinkTracker
+	if args.PreserveHardlinks {
+		hardlinks = newHardlinkTracker()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				select {
+				case <-ctx.Done():
+					// A skipped original still releases any hardlink jobs
+					// waiting on it.
+					if job.copiedDone != nil {
+						close(job.copiedDone)
+					}
+					continue
+				default:
+				}
+
+				n, err := cpJobCopy(job, bufPool, args)
+				if job.copiedDone != nil {
+					close(job.copiedDone)
+				}
+				if err != nil {
+					reportErr(err)
+					continue
+				}
+
+				progressMu.Lock()
+				bytesCopied += n
+				filesCopied++
+				if args.Progress != nil {
+					args.Progress(bytesCopied, filesCopied)
+				}
+				progressMu.Unlock()
+			}
+		}()
+	}
+
+	walkErr := filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == source {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+
+		var ownerInfo os.FileInfo
+		if args.PreserveOwner {
+			ownerInfo = info
+		}
+
+		if isSpecialFile(info.Mode()) {
+			if err := cpSpecialFile(target, info); err != nil {
+				return err
+			}
+			if ownerInfo == nil {
+				return nil
+			}
+			return chownPreserving(target, ownerInfo)
+		}
+
+		job := cpJob{
+			src:       path,
+			dst:       target,
+			mode:      info.Mode(),
+			modTime:   info.ModTime(),
+			isSymlink: info.Mode()&os.ModeSymlink != 0,
+			ownerInfo: ownerInfo,
+		}
+
+		if hardlinks != nil && info.Mode().IsRegular() {
+			if dev, ino, nlink, ok := statIdentity(info); ok {
+				entry, seen := hardlinks.observe(dev, ino, nlink, target)
+				if seen {
+					job = cpJob{dst: target, linkFrom: entry.dest, linkWait: entry.done}
+				} else {
+					job.copiedDone = entry.done
+				}
+			}
+		}
+
+		jobs <- job
+		return nil
+	})
+
+	if walkErr != nil {
+		cancel()
+	}
+	close(jobs)
+	wg.Wait()
+
+	select {
+	case err := <-errCh:
+		return err
+	default:
+	}
+
+	return walkErr
+}
+
+// cpJobCopy performs the work for a single cpJob, returning the number of
+// content bytes copied (0 for a preserved symlink).
+func cpJobCopy(job cpJob, bufPool *sync.Pool, args CpArgs) (n int64, err error) {
+	if job.linkWait != nil {
+		<-job.linkWait
+		return 0, os.Link(job.linkFrom, job.dst)
+	}
+
+	if job.isSymlink && args.PreserveLinks {
+		return 0, cpSymlink(job.src, job.dst)
+	}
+
+	in, err := os.Open(job.src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.Create(job.dst)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		cerr := out.Close()
+		if err == nil {
+			err = cerr
+		}
+	}()
+
+	bufPtr := bufPool.Get().(*[]byte)
+	defer bufPool.Put(bufPtr)
+
+	n, err = io.CopyBuffer(out, in, *bufPtr)
+	if err != nil {
+		return n, err
+	}
+
+	if err = out.Chmod(job.mode); err != nil {
+		return n, err
+	}
+
+	if args.PreserveTimestamps {
+		if err = os.Chtimes(job.dst, job.modTime, job.modTime); err != nil {
+			return n, err
+		}
+	}
+
+	if args.PreserveOwner && job.ownerInfo != nil {
+		if err = chownPreserving(job.dst, job.ownerInfo); err != nil {
+			return n, err
+		}
+	}
+
+	if args.PreserveXAttrs {
+		if err = copyXAttrs(job.src, job.dst); err != nil {
+			return n, err
+		}
+	}
+
+	err = out.Sync()
+	return n, err
+}
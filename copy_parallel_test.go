@@ -0,0 +1,89 @@
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCpRParallelWorkersAndBufferSize(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	writeFiles(t, src, "a.txt", "sub/b.txt", "sub/deeper/c.txt")
+
+	dest := filepath.Join(root, "dest")
+	if err := CpWithArgs(src, dest, CpArgs{Recursive: true, Workers: 2, BufferSize: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"a.txt", "sub/b.txt", "sub/deeper/c.txt"} {
+		want, err := os.ReadFile(filepath.Join(src, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if string(got) != string(want) {
+			t.Fatalf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCpRParallelProgress(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	writeFiles(t, src, "a.txt", "sub/b.txt", "sub/deeper/c.txt")
+
+	dest := filepath.Join(root, "dest")
+
+	var calls int64
+	var lastBytes, lastFiles int64
+	args := CpArgs{
+		Recursive: true,
+		Workers:   4,
+		Progress: func(bytesCopied, filesCopied int64) {
+			atomic.AddInt64(&calls, 1)
+			atomic.StoreInt64(&lastBytes, bytesCopied)
+			atomic.StoreInt64(&lastFiles, filesCopied)
+		},
+	}
+	if err := CpWithArgs(src, dest, args); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := atomic.LoadInt64(&calls); got != 3 {
+		t.Fatalf("expected Progress to be called once per file (3 files), got %d calls", got)
+	}
+	if lastFiles != 3 {
+		t.Fatalf("expected final filesCopied to be 3, got %d", lastFiles)
+	}
+	if lastBytes <= 0 {
+		t.Fatalf("expected final bytesCopied > 0, got %d", lastBytes)
+	}
+}
+
+func TestCpRParallelCancelsOnFirstError(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	writeFiles(t, src, "a.txt", "sub/b.txt")
+
+	// Make one source file unreadable so its copy job fails; the recursive
+	// copy should still return that error rather than hanging or silently
+	// succeeding.
+	if err := os.Chmod(filepath.Join(src, "sub", "b.txt"), 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(filepath.Join(src, "sub", "b.txt"), 0644)
+
+	if os.Geteuid() == 0 {
+		t.Skip("running as root, permission bits are not enforced")
+	}
+
+	dest := filepath.Join(root, "dest")
+	if err := CpWithArgs(src, dest, CpArgs{Recursive: true, Workers: 2}); err == nil {
+		t.Fatal("expected an error from the unreadable source file")
+	}
+}
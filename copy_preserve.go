@@ -0,0 +1,78 @@
+package fileutils
+
+import (
+	"errors"
+	"os"
+)
+
+// errUnsupportedSpecialFile is returned by cpSpecialFile when the current
+// platform (or the source's os.FileInfo) doesn't expose enough information
+// to recreate a device or FIFO.
+var errUnsupportedSpecialFile = errors.New("fileutils: devices and FIFOs are not supported on this platform")
+
+// inodeKey identifies a file uniquely on a single filesystem, used to detect
+// hardlinks during a recursive copy.
+type inodeKey struct {
+	dev, ino uint64
+}
+
+// hardlinkTracker records, for every source inode seen so far during a
+// recursive copy, the destination path its content is being copied to and a
+// channel that closes once that copy finishes. Since the walk is sequential
+// but copies happen on worker goroutines, later sightings of the same inode
+// must wait for that channel before calling os.Link, rather than linking to
+// a destination that may not exist yet.
+type hardlinkTracker struct {
+	seen map[inodeKey]hardlinkEntry
+}
+
+type hardlinkEntry struct {
+	dest string
+	done chan struct{}
+}
+
+func newHardlinkTracker() *hardlinkTracker {
+	return &hardlinkTracker{seen: make(map[inodeKey]hardlinkEntry)}
+}
+
+// observe returns the existing entry for the (dev, ino) identity, and true,
+// if it has already been copied once before; otherwise it records a fresh
+// entry (with an open done channel the caller must close once its copy
+// completes) and returns false. nlink < 2 always returns false, since such a
+// file has no other names to dedupe against.
+func (h *hardlinkTracker) observe(dev, ino, nlink uint64, dest string) (hardlinkEntry, bool) {
+	if nlink < 2 {
+		return hardlinkEntry{}, false
+	}
+
+	key := inodeKey{dev: dev, ino: ino}
+	if existing, ok := h.seen[key]; ok {
+		return existing, true
+	}
+
+	entry := hardlinkEntry{dest: dest, done: make(chan struct{})}
+	h.seen[key] = entry
+	return entry, false
+}
+
+// splitNulTerminated splits a buffer of NUL-terminated strings, as returned
+// by listxattr(2), into a slice of names.
+func splitNulTerminated(buf []byte) []string {
+	var names []string
+	start := 0
+	for i, b := range buf {
+		if b == 0 {
+			if i > start {
+				names = append(names, string(buf[start:i]))
+			}
+			start = i + 1
+		}
+	}
+	return names
+}
+
+// isSpecialFile reports whether mode describes a device or FIFO, which
+// cannot be copied by reading and writing content.
+func isSpecialFile(mode os.FileMode) bool {
+	return mode&(os.ModeDevice|os.ModeCharDevice|os.ModeNamedPipe) != 0
+}
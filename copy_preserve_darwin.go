@@ -0,0 +1,56 @@
+//go:build darwin
+
+package fileutils
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// statIdentity extracts the (dev, ino, nlink) identity of info as reported
+// by Lstat, for hardlink detection.
+func statIdentity(info os.FileInfo) (dev, ino, nlink uint64, ok bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	return uint64(st.Dev), st.Ino, uint64(st.Nlink), true
+}
+
+// chownPreserving chows path to match info's owner, silently ignoring a
+// permission failure since copying as a non-privileged user (without
+// CAP_CHOWN) cannot change ownership and should not abort the copy.
+func chownPreserving(path string, info os.FileInfo) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	if err := os.Chown(path, int(st.Uid), int(st.Gid)); err != nil && !errors.Is(err, os.ErrPermission) {
+		return err
+	}
+
+	return nil
+}
+
+// cpSpecialFile recreates a character/block device or FIFO at dest using the
+// same major/minor numbers as src.
+func cpSpecialFile(dest string, info os.FileInfo) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return &os.PathError{Op: "mknod", Path: dest, Err: errUnsupportedSpecialFile}
+	}
+
+	mode := uint32(info.Mode().Perm())
+	switch {
+	case info.Mode()&os.ModeNamedPipe != 0:
+		mode |= syscall.S_IFIFO
+	case info.Mode()&os.ModeCharDevice != 0:
+		mode |= syscall.S_IFCHR
+	default:
+		mode |= syscall.S_IFBLK
+	}
+
+	return syscall.Mknod(dest, mode, int(st.Rdev))
+}
@@ -0,0 +1,40 @@
+//go:build linux
+
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestCpWithArgsPreservesXAttrs(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(source, []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	const name, value = "user.fileutils-test", "hello"
+	if err := syscall.Setxattr(source, name, []byte(value), 0); err != nil {
+		t.Skipf("xattrs not supported on this filesystem: %v", err)
+	}
+
+	dest := filepath.Join(root, "b.txt")
+	if err := CpWithArgs(source, dest, CpArgs{PreserveXAttrs: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := syscall.Getxattr(dest, name, nil)
+	if err != nil {
+		t.Fatalf("expected xattr %s to be copied, got: %v", name, err)
+	}
+	buf := make([]byte, size)
+	if _, err := syscall.Getxattr(dest, name, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != value {
+		t.Fatalf("got xattr value %q, want %q", buf, value)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !linux && !darwin
+
+package fileutils
+
+import "os"
+
+// statIdentity has no inode identity to report on non-unix platforms, so
+// hardlink detection is always a no-op there.
+func statIdentity(info os.FileInfo) (dev, ino, nlink uint64, ok bool) {
+	return 0, 0, 0, false
+}
+
+// chownPreserving is a no-op on platforms without a uid/gid ownership model.
+func chownPreserving(path string, info os.FileInfo) error {
+	return nil
+}
+
+// cpSpecialFile is unsupported on platforms without a mknod(2) equivalent.
+func cpSpecialFile(dest string, info os.FileInfo) error {
+	return &os.PathError{Op: "mknod", Path: dest, Err: errUnsupportedSpecialFile}
+}
@@ -0,0 +1,72 @@
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCpRParallelPreservesHardlinks(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	original := filepath.Join(src, "a.txt")
+	if err := os.WriteFile(original, []byte("shared content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	linked := filepath.Join(src, "b.txt")
+	if err := os.Link(original, linked); err != nil {
+		t.Skipf("hardlinks not supported here: %v", err)
+	}
+
+	info, err := os.Lstat(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, ok := statIdentity(info); !ok {
+		t.Skip("statIdentity unsupported on " + runtime.GOOS)
+	}
+
+	dest := filepath.Join(root, "dest")
+	args := CpArgs{Recursive: true, PreserveHardlinks: true, Workers: 4}
+	if err := CpWithArgs(src, dest, args); err != nil {
+		t.Fatal(err)
+	}
+
+	aInfo, err := os.Lstat(filepath.Join(dest, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bInfo, err := os.Lstat(filepath.Join(dest, "b.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, aIno, _, _ := statIdentity(aInfo)
+	_, bIno, _, _ := statIdentity(bInfo)
+	if aIno != bIno {
+		t.Fatalf("expected a.txt and b.txt to share an inode at dest, got %d and %d", aIno, bIno)
+	}
+}
+
+func TestCpWithArgsPreservesOwner(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(source, []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(root, "b.txt")
+	// Chowning to the invoking user's own UID/GID always succeeds without
+	// CAP_CHOWN, so this is a reasonable smoke test in unprivileged CI too.
+	if err := CpWithArgs(source, dest, CpArgs{PreserveOwner: true}); err != nil {
+		t.Fatalf("expected PreserveOwner copy to succeed, got: %v", err)
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Fatal(err)
+	}
+}
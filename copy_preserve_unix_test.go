@@ -0,0 +1,31 @@
+//go:build linux || darwin
+
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestCpWithArgsCopiesFIFO(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "fifo")
+	if err := syscall.Mkfifo(source, 0644); err != nil {
+		t.Skipf("FIFOs not supported here: %v", err)
+	}
+
+	dest := filepath.Join(root, "fifo-copy")
+	if err := CpWithArgs(source, dest, CpArgs{}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeNamedPipe == 0 {
+		t.Fatalf("expected %s to be a FIFO, got mode %v", dest, info.Mode())
+	}
+}
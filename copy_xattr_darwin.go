@@ -0,0 +1,104 @@
+//go:build darwin
+
+package fileutils
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// copyXAttrs copies every extended attribute from src to dst.
+func copyXAttrs(src, dst string) error {
+	size, err := listxattr(src)
+	if err != nil {
+		return err
+	}
+	if len(size) == 0 {
+		return nil
+	}
+
+	for _, name := range splitNulTerminated(size) {
+		val, err := getxattr(src, name)
+		if err != nil {
+			return err
+		}
+		if err := setxattr(dst, name, val); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func listxattr(path string) ([]byte, error) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	n, _, errno := syscall.Syscall6(syscall.SYS_LISTXATTR, uintptr(unsafe.Pointer(p)), 0, 0, 0, 0, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	buf := make([]byte, n)
+	n, _, errno = syscall.Syscall6(syscall.SYS_LISTXATTR, uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&buf[0])), n, 0, 0, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	return buf[:n], nil
+}
+
+func getxattr(path, name string) ([]byte, error) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	n, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	size, _, errno := syscall.Syscall6(syscall.SYS_GETXATTR, uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(n)), 0, 0, 0, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	if size == 0 {
+		return nil, nil
+	}
+
+	buf := make([]byte, size)
+	size, _, errno = syscall.Syscall6(syscall.SYS_GETXATTR, uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(n)), uintptr(unsafe.Pointer(&buf[0])), size, 0, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	return buf[:size], nil
+}
+
+func setxattr(path, name string, val []byte) error {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return err
+	}
+	n, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return err
+	}
+
+	var valPtr unsafe.Pointer
+	if len(val) > 0 {
+		valPtr = unsafe.Pointer(&val[0])
+	}
+
+	_, _, errno := syscall.Syscall6(syscall.SYS_SETXATTR, uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(n)), uintptr(valPtr), uintptr(len(val)), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
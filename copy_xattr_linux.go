@@ -0,0 +1,43 @@
+//go:build linux
+
+package fileutils
+
+import "syscall"
+
+// copyXAttrs copies every extended attribute from src to dst.
+func copyXAttrs(src, dst string) error {
+	size, err := syscall.Listxattr(src, nil)
+	if err != nil {
+		if err == syscall.ENOTSUP {
+			return nil
+		}
+		return err
+	}
+	if size == 0 {
+		return nil
+	}
+
+	buf := make([]byte, size)
+	n, err := syscall.Listxattr(src, buf)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range splitNulTerminated(buf[:n]) {
+		valSize, err := syscall.Getxattr(src, name, nil)
+		if err != nil {
+			return err
+		}
+		val := make([]byte, valSize)
+		if valSize > 0 {
+			if _, err := syscall.Getxattr(src, name, val); err != nil {
+				return err
+			}
+		}
+		if err := syscall.Setxattr(dst, name, val, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package fileutils
+
+// copyXAttrs is a no-op on platforms without a supported xattr syscall
+// interface.
+func copyXAttrs(src, dst string) error {
+	return nil
+}
@@ -0,0 +1,286 @@
+// Package fileop provides a fluent, batched file operation builder: record a
+// sequence of Mkdir/Copy/Rm steps and Commit them as a single unit, with a
+// Dry run preview and a best-effort Rollback if a later step fails.
+package fileop
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/DevTable/go-fileutils"
+)
+
+// MkdirOpts configures an Op.Mkdir step.
+type MkdirOpts struct {
+	// Parents creates any missing parent directories, like `mkdir -p`.
+	Parents bool
+}
+
+// ChownOpt sets the owner applied after an Op.Copy step.
+type ChownOpt struct {
+	UID, GID int
+}
+
+// CopyOpts configures an Op.Copy step.
+type CopyOpts struct {
+	Recursive      bool
+	FollowSymlinks bool
+	// AllowWildcard treats src as a glob pattern (see fileutils.CpGlob)
+	// instead of a literal path.
+	AllowWildcard bool
+	// Chown, if set, chowns the copied destination afterward.
+	Chown *ChownOpt
+}
+
+// RmOpts configures an Op.Rm step.
+type RmOpts struct {
+	Recursive bool
+	// AllowNotFound turns a missing path into a no-op instead of an error.
+	AllowNotFound bool
+}
+
+type stepKind int
+
+const (
+	stepMkdir stepKind = iota
+	stepCopy
+	stepRm
+)
+
+type step struct {
+	kind stepKind
+	path string
+	src  string
+	mode os.FileMode
+
+	mkdirOpts MkdirOpts
+	copyOpts  CopyOpts
+	rmOpts    RmOpts
+}
+
+// Op is a batch of file operations recorded via Mkdir/Copy/Rm and executed in
+// order by Commit.
+type Op struct {
+	steps   []step
+	created []string
+}
+
+// New returns an empty Op.
+func New() *Op {
+	return &Op{}
+}
+
+// Mkdir records a directory creation step.
+func (op *Op) Mkdir(path string, mode os.FileMode, opts MkdirOpts) *Op {
+	op.steps = append(op.steps, step{kind: stepMkdir, path: path, mode: mode, mkdirOpts: opts})
+	return op
+}
+
+// Copy records a copy step from src to dst.
+func (op *Op) Copy(src, dst string, opts CopyOpts) *Op {
+	op.steps = append(op.steps, step{kind: stepCopy, src: src, path: dst, copyOpts: opts})
+	return op
+}
+
+// Rm records a removal step.
+func (op *Op) Rm(path string, opts RmOpts) *Op {
+	op.steps = append(op.steps, step{kind: stepRm, path: path, rmOpts: opts})
+	return op
+}
+
+// Dry returns the shell-equivalent command for each recorded step, in order,
+// for auditing a batch before running it.
+func (op *Op) Dry() []string {
+	lines := make([]string, 0, len(op.steps))
+	for _, s := range op.steps {
+		switch s.kind {
+		case stepMkdir:
+			if s.mkdirOpts.Parents {
+				lines = append(lines, fmt.Sprintf("mkdir -p -m %o %s", s.mode, s.path))
+			} else {
+				lines = append(lines, fmt.Sprintf("mkdir -m %o %s", s.mode, s.path))
+			}
+		case stepCopy:
+			flags := ""
+			if s.copyOpts.Recursive {
+				flags += "-R "
+			}
+			if !s.copyOpts.FollowSymlinks {
+				flags += "-P "
+			}
+			lines = append(lines, fmt.Sprintf("cp %s%s %s", flags, s.src, s.path))
+			if s.copyOpts.Chown != nil {
+				lines = append(lines, fmt.Sprintf("chown -R %d:%d %s", s.copyOpts.Chown.UID, s.copyOpts.Chown.GID, s.path))
+			}
+		case stepRm:
+			if s.rmOpts.Recursive {
+				lines = append(lines, fmt.Sprintf("rm -rf %s", s.path))
+			} else if s.rmOpts.AllowNotFound {
+				lines = append(lines, fmt.Sprintf("rm -f %s", s.path))
+			} else {
+				lines = append(lines, fmt.Sprintf("rm %s", s.path))
+			}
+		}
+	}
+	return lines
+}
+
+// Commit executes every recorded step in order. If a step fails, Commit
+// rolls back the paths created by earlier steps in this Commit (best
+// effort) before returning the step's error.
+func (op *Op) Commit() error {
+	op.created = op.created[:0]
+
+	for _, s := range op.steps {
+		if err := op.apply(s); err != nil {
+			op.Rollback()
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rollback best-effort removes the target path of every Mkdir and Copy step
+// of the most recent Commit that did not exist before that step ran, most
+// recently created first. Missing parent directories created along the way
+// (by MkdirOpts.Parents or a recursive Copy to a new path) are not recorded
+// and are left in place. Errors are ignored since rollback already runs in a
+// failure path; the first one encountered, if any, is returned for
+// diagnostics.
+func (op *Op) Rollback() error {
+	var firstErr error
+	for i := len(op.created) - 1; i >= 0; i-- {
+		if err := os.RemoveAll(op.created[i]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	op.created = nil
+	return firstErr
+}
+
+func (op *Op) apply(s step) error {
+	switch s.kind {
+	case stepMkdir:
+		return op.applyMkdir(s)
+	case stepCopy:
+		return op.applyCopy(s)
+	case stepRm:
+		return op.applyRm(s)
+	default:
+		return fmt.Errorf("fileop: unknown step kind %d", s.kind)
+	}
+}
+
+func (op *Op) applyMkdir(s step) error {
+	existed := pathExists(s.path)
+
+	var err error
+	if s.mkdirOpts.Parents {
+		err = fileutils.MkdirP(s.path, s.mode)
+	} else {
+		err = os.Mkdir(s.path, s.mode)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !existed {
+		op.created = append(op.created, s.path)
+	}
+	return nil
+}
+
+func (op *Op) applyCopy(s step) error {
+	existed := pathExists(s.path)
+
+	opts := fileutils.CpOptions{
+		Recursive: s.copyOpts.Recursive,
+		// A Copy step targeting a directory that already exists (including
+		// one an earlier Mkdir step in this same Op just created) should
+		// merge into it rather than aborting, matching the image-prep
+		// workflows this package is meant for.
+		OnDirExists: func(src, dest string) fileutils.DirExistsAction {
+			return fileutils.DirMerge
+		},
+	}
+	if !s.copyOpts.FollowSymlinks {
+		opts.OnSymlink = func(src string) fileutils.SymlinkAction {
+			return fileutils.SymlinkShallow
+		}
+	}
+
+	var err error
+	if s.copyOpts.AllowWildcard {
+		err = copyGlobWithOptions(s.src, s.path, opts)
+	} else {
+		err = fileutils.CpWithOptions(s.src, s.path, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !existed {
+		op.created = append(op.created, s.path)
+	}
+
+	if s.copyOpts.Chown != nil {
+		if err := fileutils.ChownR(s.path, s.copyOpts.Chown.UID, s.copyOpts.Chown.GID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (op *Op) applyRm(s step) error {
+	if s.rmOpts.AllowNotFound {
+		if _, err := os.Lstat(s.path); os.IsNotExist(err) {
+			return nil
+		}
+	}
+
+	if s.rmOpts.Recursive {
+		return fileutils.RmRF(s.path)
+	}
+	return fileutils.Rm(s.path)
+}
+
+// copyGlobWithOptions is fileutils.CpGlob's destination-directory handling,
+// but driving fileutils.CpWithOptions instead of fileutils.CpWithArgs so
+// wildcard Copy steps get the same DirMerge/symlink behavior as a literal
+// source.
+func copyGlobWithOptions(pattern, dest string, opts fileutils.CpOptions) error {
+	matches, err := fileutils.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return &os.PathError{Op: "cpglob", Path: pattern, Err: os.ErrNotExist}
+	}
+
+	destIsDir := len(matches) > 1
+	if !destIsDir {
+		if info, err := os.Stat(dest); err == nil && info.IsDir() {
+			destIsDir = true
+		}
+	}
+
+	for _, match := range matches {
+		target := dest
+		if destIsDir {
+			target = filepath.Join(dest, filepath.Base(match))
+		}
+		if err := fileutils.CpWithOptions(match, target, opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
@@ -0,0 +1,105 @@
+package fileop
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommitMkdirAndCopy(t *testing.T) {
+	base := t.TempDir()
+	src := filepath.Join(base, "src")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(base, "out", "copied")
+	op := New()
+	op.Mkdir(filepath.Join(base, "out"), 0755, MkdirOpts{Parents: true})
+	op.Copy(src, dst, CopyOpts{Recursive: true})
+
+	if err := op.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommitCopyIntoExistingDestDir(t *testing.T) {
+	base := t.TempDir()
+	src := filepath.Join(base, "src")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(base, "out")
+	op := New()
+	op.Mkdir(dst, 0755, MkdirOpts{Parents: true})
+	op.Copy(src, dst, CopyOpts{Recursive: true})
+
+	if err := op.Commit(); err != nil {
+		t.Fatalf("Commit into a directory this Op just created should merge, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommitRollsBackOnFailure(t *testing.T) {
+	base := t.TempDir()
+
+	op := New()
+	created := filepath.Join(base, "out2")
+	op.Mkdir(created, 0755, MkdirOpts{Parents: true})
+	op.Copy(filepath.Join(base, "does-not-exist"), filepath.Join(created, "x"), CopyOpts{Recursive: true})
+
+	if err := op.Commit(); err == nil {
+		t.Fatal("expected Commit to fail")
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Fatalf("expected rollback to remove %s, stat err = %v", created, err)
+	}
+}
+
+func TestCommitRm(t *testing.T) {
+	base := t.TempDir()
+	target := filepath.Join(base, "gone")
+	if err := os.WriteFile(target, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	op := New()
+	op.Rm(target, RmOpts{})
+	if err := op.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be removed", target)
+	}
+
+	// A second Rm of the same now-missing path should no-op with AllowNotFound.
+	op2 := New()
+	op2.Rm(target, RmOpts{AllowNotFound: true})
+	if err := op2.Commit(); err != nil {
+		t.Fatalf("expected AllowNotFound to tolerate a missing path, got: %v", err)
+	}
+}
+
+func TestDry(t *testing.T) {
+	op := New()
+	op.Mkdir("/a/b", 0755, MkdirOpts{Parents: true})
+	op.Copy("/src", "/dst", CopyOpts{Recursive: true})
+	op.Rm("/tmp/x", RmOpts{Recursive: true, AllowNotFound: true})
+
+	lines := op.Dry()
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %v", lines)
+	}
+}
@@ -3,9 +3,7 @@ package fileutils
 
 import (
 	"errors"
-	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -16,8 +14,37 @@ type CpArgs struct {
 	Recursive          bool
 	PreserveLinks      bool
 	PreserveTimestamps bool
+
+	// Workers is the number of goroutines used to copy files during a
+	// recursive copy. Defaults to runtime.NumCPU() when <= 0.
+	Workers int
+	// BufferSize is the size, in bytes, of the buffers used to copy file
+	// content. Defaults to 8 KiB when <= 0. Buffers are pooled and reused
+	// across copies within a single recursive copy.
+	BufferSize int
+	// Progress, if non-nil, is called after each file is copied during a
+	// recursive copy with the cumulative bytes and files copied so far.
+	Progress func(bytesCopied, filesCopied int64)
+
+	// PreserveOwner chowns every copied file, device and FIFO to match its
+	// source's UID/GID. Directories, and symlinks recreated by PreserveLinks,
+	// are not chowned. A permission error (no CAP_CHOWN) is ignored rather
+	// than failing the copy.
+	PreserveOwner bool
+	// PreserveXAttrs copies extended attributes from each copied file to its
+	// destination. Directories, devices, FIFOs and symlinks recreated by
+	// PreserveLinks get no extended attributes.
+	PreserveXAttrs bool
+	// PreserveHardlinks recreates hardlinks found in the source tree as
+	// hardlinks in the destination, instead of duplicating their content.
+	PreserveHardlinks bool
 }
 
+var (
+	errDirectorySource   = errors.New("source is a directory")
+	errDestinationExists = errors.New("destination already exists")
+)
+
 // ChmodR is like `chmod -R`
 func ChmodR(name string, mode os.FileMode) error {
 	return filepath.Walk(name, func(path string, info os.FileInfo, err error) error {
@@ -77,32 +104,15 @@ func CpWithArgs(source, dest string, args CpArgs) (err error) {
 	if sourceInfo.IsDir() {
 		// Handle the dir case
 		if !args.Recursive {
-			return errors.New("source is a directory")
+			return errDirectorySource
 		}
 
 		// ensure dest dir does not already exist
 		if _, err = os.Open(dest); !os.IsNotExist(err) {
-			return errors.New("destination already exists")
+			return errDestinationExists
 		}
 
-		// create dest dir
-		if err = os.MkdirAll(dest, sourceInfo.Mode()); err != nil {
-			return
-		}
-
-		files, err := ioutil.ReadDir(source)
-		if err != nil {
-			return err
-		}
-
-		for _, file := range files {
-			sourceFilePath := fmt.Sprintf("%s/%s", source, file.Name())
-			destFilePath := fmt.Sprintf("%s/%s", dest, file.Name())
-
-			if err = CpWithArgs(sourceFilePath, destFilePath, args); err != nil {
-				return err
-			}
-		}
+		return cpRecursiveParallel(source, dest, sourceInfo, args)
 	} else {
 		// Handle the file case
 		si, err := os.Lstat(source)
@@ -110,10 +120,20 @@ func CpWithArgs(source, dest string, args CpArgs) (err error) {
 			return err
 		}
 
-		if args.PreserveLinks && !si.Mode().IsRegular() {
+		if args.PreserveLinks && si.Mode()&os.ModeSymlink != 0 {
 			return cpSymlink(source, dest)
 		}
 
+		if isSpecialFile(si.Mode()) {
+			if err = cpSpecialFile(dest, si); err != nil {
+				return err
+			}
+			if args.PreserveOwner {
+				return chownPreserving(dest, si)
+			}
+			return nil
+		}
+
 		//open source
 		in, err := os.Open(source)
 		if err != nil {
@@ -148,6 +168,18 @@ func CpWithArgs(source, dest string, args CpArgs) (err error) {
 			}
 		}
 
+		if args.PreserveOwner {
+			if err = chownPreserving(dest, si); err != nil {
+				return err
+			}
+		}
+
+		if args.PreserveXAttrs {
+			if err = copyXAttrs(source, dest); err != nil {
+				return err
+			}
+		}
+
 		//sync dest to disk
 		err = out.Sync()
 	}
@@ -0,0 +1,139 @@
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// globMatch reports whether name matches pattern, where pattern may contain
+// "**" path segments meaning "zero or more path segments" in addition to the
+// usual filepath.Match wildcards within a single segment.
+func globMatch(pattern, name string) (bool, error) {
+	patternParts := strings.Split(filepath.ToSlash(pattern), "/")
+	nameParts := strings.Split(filepath.ToSlash(name), "/")
+	return matchParts(patternParts, nameParts)
+}
+
+func matchParts(pattern, name []string) (bool, error) {
+	if len(pattern) == 0 {
+		return len(name) == 0, nil
+	}
+
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			return true, nil
+		}
+		for i := 0; i <= len(name); i++ {
+			ok, err := matchParts(pattern[1:], name[i:])
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	if len(name) == 0 {
+		return false, nil
+	}
+
+	ok, err := filepath.Match(pattern[0], name[0])
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return matchParts(pattern[1:], name[1:])
+}
+
+// globRoot returns the directory to start walking from for pattern: the
+// longest leading path that contains no wildcard characters.
+func globRoot(pattern string) string {
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	var root []string
+	for _, p := range parts {
+		if strings.ContainsAny(p, "*?[") {
+			break
+		}
+		root = append(root, p)
+	}
+	if len(root) == 0 {
+		return "."
+	}
+	return filepath.FromSlash(strings.Join(root, "/"))
+}
+
+// Glob expands a shell-style pattern that may include doublestar ("**")
+// segments, returning the sorted list of matching paths. Unlike filepath.Glob,
+// "**" matches any number of path segments, letting callers write patterns
+// like "src/**/*.go".
+func Glob(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "*") && !strings.ContainsAny(pattern, "?[") {
+		if _, err := os.Stat(pattern); err != nil {
+			return nil, err
+		}
+		return []string{pattern}, nil
+	}
+
+	root := globRoot(pattern)
+	var matches []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root && root == pattern {
+			return nil
+		}
+		ok, err := globMatch(pattern, path)
+		if err != nil {
+			return err
+		}
+		if ok {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// CpGlob copies every path matching pattern (a shell-style glob, optionally
+// containing "**" segments) into dest. If pattern expands to more than one
+// match, or dest already exists as a directory, dest is treated as a
+// destination directory and each match is copied into it by base name;
+// otherwise dest is used as-is.
+func CpGlob(pattern, dest string, args CpArgs) error {
+	matches, err := Glob(pattern)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return &os.PathError{Op: "cpglob", Path: pattern, Err: os.ErrNotExist}
+	}
+
+	destIsDir := len(matches) > 1
+	if !destIsDir {
+		if info, err := os.Stat(dest); err == nil && info.IsDir() {
+			destIsDir = true
+		}
+	}
+
+	for _, match := range matches {
+		target := dest
+		if destIsDir {
+			target = filepath.Join(dest, filepath.Base(match))
+		}
+		if err := CpWithArgs(match, target, args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
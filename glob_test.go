@@ -0,0 +1,92 @@
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		full := filepath.Join(root, p)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGlobDoubleStar(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root,
+		"src/a.go",
+		"src/sub/b.go",
+		"src/sub/deeper/c.go",
+		"src/sub/deeper/c.txt",
+	)
+
+	matches, err := Glob(filepath.Join(root, "src/**/*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, m := range matches {
+		rel, err := filepath.Rel(root, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, filepath.ToSlash(rel))
+	}
+	sort.Strings(got)
+
+	// "**" can match zero segments, so "src/**/*.go" also matches "src/a.go"
+	// directly, consistent with how doublestar patterns behave elsewhere.
+	want := []string{"src/a.go", "src/sub/deeper/c.go", "src/sub/b.go"}
+	sort.Strings(want)
+
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestGlobNoMatches(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "src/a.go")
+
+	matches, err := Glob(filepath.Join(root, "src/**/*.rb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 0 {
+		t.Fatalf("expected no matches, got %v", matches)
+	}
+}
+
+func TestCpGlobMultipleMatchesIntoDir(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "src/a.txt", "src/b.txt")
+	dest := filepath.Join(root, "dest")
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CpGlob(filepath.Join(root, "src/*.txt"), dest, CpArgs{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if _, err := os.Stat(filepath.Join(dest, name)); err != nil {
+			t.Fatalf("expected %s to be copied: %v", name, err)
+		}
+	}
+}